@@ -1,9 +1,11 @@
 package jsonrpc
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
+	"strconv"
 	"sync"
 )
 
@@ -25,9 +27,10 @@ type clientResponse struct {
 }
 
 type clientRequest struct {
-	ID     int    `json:"id"`
-	Method string `json:"method"`
-	Params any    `json:"params,omitempty"`
+	JSONRPC string `json:"jsonrpc,omitempty"`
+	ID      any    `json:"id,omitempty"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
 }
 
 // Client represents a client connection to a JSONRPC server.
@@ -36,23 +39,39 @@ type Client struct {
 }
 
 type clientHandler struct {
-	encoder *json.Encoder
-	decoder *json.Decoder
-	closer  io.Closer
+	stream Stream
+
+	strict bool
 
 	mu       sync.Mutex
 	nextID   int
 	requests map[int]chan clientResponse
 	waits    map[int]*wait
+	subs     map[string]*ClientSubscription
+}
+
+// NewClient creates a new client from the given connection, treating rw as
+// a newline-delimited JSON stream. It is a thin wrapper over
+// NewClientFromStream and NewStdioStream.
+func NewClient(rw ReadWriteCloser, opts ...Option) *Client {
+	return NewClientFromStream(NewStdioStream(rw), opts...)
 }
 
-// NewClient create a new client from the given connection.
-func NewClient(rw ReadWriteCloser) *Client {
+// NewClientFromStream creates a new client reading responses from, and
+// writing requests to, stream.
+//
+// Calls this client makes are numbered with positive integers starting at
+// 1. 0 is never allocated, and the negative range stays free for the legacy
+// Await/Subscribe push pattern, so no id this client allocates can ever
+// collide with one.
+func NewClientFromStream(stream Stream, opts ...Option) *Client {
+	o := applyOptions(opts)
+
 	c := &Client{
 		clientHandler: clientHandler{
-			decoder:  json.NewDecoder(rw),
-			closer:   rw,
-			encoder:  json.NewEncoder(rw),
+			stream:   stream,
+			strict:   o.strict,
+			nextID:   1,
 			requests: make(map[int]chan clientResponse),
 			waits:    make(map[int]*wait),
 		},
@@ -63,15 +82,41 @@ func NewClient(rw ReadWriteCloser) *Client {
 	return c
 }
 
+// clientMessage decodes anything the connected peer may send: a response to
+// a call this client made, or a subscription notification.
+type clientMessage struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
 func (c *Client) respond() {
 	for {
-		var resp clientResponse
+		raw, err := c.stream.Read()
+		if err != nil {
+			c.teardownSubs(err)
+			return
+		}
 
-		if err := c.decoder.Decode(&resp); err != nil {
+		var msg clientMessage
+
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			c.teardownSubs(err)
 			return
 		}
 
-		c.handleResponse(resp)
+		if msg.Method == subscriptionMethod {
+			c.handleSubscription(msg.Params)
+			continue
+		}
+
+		c.handleResponse(clientResponse{
+			ID:     msg.ID,
+			Result: msg.Result,
+			Error:  msg.Error,
+		})
 	}
 }
 
@@ -103,21 +148,7 @@ func (c *clientHandler) handleResponse(resp clientResponse) {
 //
 // Returns the JSON encoded response from the server, or an error.
 func (c *clientHandler) Request(method string, params any) (json.RawMessage, error) {
-	ch := make(chan clientResponse)
-
-	c.mu.Lock()
-
-	id := c.nextID
-	c.nextID++
-	c.requests[id] = ch
-
-	c.mu.Unlock()
-
-	c.encoder.Encode(clientRequest{
-		ID:     id,
-		Method: method,
-		Params: params,
-	})
+	_, ch := c.send(method, params)
 
 	resp := <-ch
 
@@ -139,10 +170,114 @@ func (c *clientHandler) RequestValue(method string, params any, response any) er
 	return json.Unmarshal(respData, response)
 }
 
+// RequestContext acts as Request, but aborts the call if ctx is cancelled
+// before a response arrives: the pending entry is removed without leaking
+// the goroutine waiting on the response, and Cancel is used to ask the
+// server to stop servicing it.
+func (c *clientHandler) RequestContext(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	id, ch := c.send(method, params)
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+
+		return resp.Result, nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.requests, id)
+		c.mu.Unlock()
+
+		c.Cancel(id)
+
+		return nil, ctx.Err()
+	}
+}
+
+// RequestValueContext acts as RequestContext, but will unmarshal the
+// response into the given value.
+func (c *clientHandler) RequestValueContext(ctx context.Context, method string, params any, response any) error {
+	respData, err := c.RequestContext(ctx, method, params)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(respData, response)
+}
+
+// send encodes and writes the request, recording a buffered response channel
+// against its id so a late or unread response never blocks the decode loop.
+func (c *clientHandler) send(method string, params any) (int, chan clientResponse) {
+	ch := make(chan clientResponse, 1)
+
+	c.mu.Lock()
+
+	id := c.nextID
+	c.nextID++
+	c.requests[id] = ch
+
+	req := clientRequest{
+		ID:     id,
+		Method: method,
+		Params: params,
+	}
+	if c.strict {
+		req.JSONRPC = Version
+	}
+
+	c.mu.Unlock()
+
+	c.writeJSON(req)
+
+	return id, ch
+}
+
+// writeJSON marshals v and writes it to the stream as a single message.
+func (c *clientHandler) writeJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return c.stream.Write(data)
+}
+
+// Notify sends method and params to the peer as a fire-and-forget request:
+// it carries no id, allocates no response slot, and the peer sends no
+// response to it.
+func (c *clientHandler) Notify(method string, params any) error {
+	req := clientRequest{
+		Method: method,
+		Params: params,
+	}
+	if c.strict {
+		req.JSONRPC = Version
+	}
+
+	return c.writeJSON(req)
+}
+
+// Cancel sends a notification asking the server to abort the in-flight
+// request with the given id. It does not wait for, or guarantee, the server
+// honouring the request.
+func (c *clientHandler) Cancel(id int) error {
+	req := clientRequest{
+		Method: cancelMethod,
+		Params: cancelParams{ID: json.RawMessage(strconv.Itoa(id))},
+	}
+	if c.strict {
+		req.JSONRPC = Version
+	}
+
+	return c.writeJSON(req)
+}
+
 // Await will wait for a message pushed from the server with the given ID and
 // call the given func with the JSON encoded data.
 //
-// The id given should be a negative value.
+// The id given should be a negative value. This is a legacy pattern, predating
+// the Strict option, and works the same under either mode.
 func (c *clientHandler) Await(id int, cb func(json.RawMessage)) error {
 	return c.wait(id, cb, false)
 }
@@ -150,7 +285,8 @@ func (c *clientHandler) Await(id int, cb func(json.RawMessage)) error {
 // Subscribe will wait for all messages pushed from the server with the given
 // ID and call the given func with the JSON encoded data for each one.
 //
-// The id given should be a negative value.
+// The id given should be a negative value. This is a legacy pattern, predating
+// the Strict option, and works the same under either mode.
 func (c *clientHandler) Subscribe(id int, cb func(json.RawMessage)) error {
 	return c.wait(id, cb, true)
 }
@@ -185,7 +321,9 @@ func (c *clientHandler) Close() error {
 
 	c.mu.Unlock()
 
-	return c.closer.Close()
+	c.teardownSubs(errors.New("conn closed"))
+
+	return c.stream.Close()
 }
 
 // Error.
@@ -0,0 +1,168 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// ErrInvalidChannel is returned by SubscribeContext when ch is not a channel
+// that values can be sent on.
+var ErrInvalidChannel = errors.New("jsonrpc: ch must be a sendable channel")
+
+// ClientSubscription represents a subscription created by SubscribeContext.
+type ClientSubscription struct {
+	c  *clientHandler
+	id string
+	ch reflect.Value
+
+	raw       chan json.RawMessage
+	errC      chan error
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// SubscribeContext calls method with args to begin a subscription, then
+// delivers each notification's result, unmarshalled into ch's element type,
+// on ch until Unsubscribe is called or the connection is lost.
+//
+// ch must be a channel with a send direction. The subscription is
+// identified by whatever id the server's response to method contains.
+func (c *clientHandler) SubscribeContext(ctx context.Context, method string, args any, ch any) (*ClientSubscription, error) {
+	chVal := reflect.ValueOf(ch)
+	if chVal.Kind() != reflect.Chan || chVal.Type().ChanDir()&reflect.SendDir == 0 {
+		return nil, ErrInvalidChannel
+	}
+
+	var id string
+	if err := c.RequestValueContext(ctx, method, args, &id); err != nil {
+		return nil, err
+	}
+
+	sub := &ClientSubscription{
+		c:       c,
+		id:      id,
+		ch:      chVal,
+		raw:     make(chan json.RawMessage, 16),
+		errC:    make(chan error, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	c.mu.Lock()
+	if c.subs == nil {
+		c.subs = make(map[string]*ClientSubscription)
+	}
+	c.subs[id] = sub
+	c.mu.Unlock()
+
+	go sub.pump()
+
+	return sub, nil
+}
+
+// pump unmarshals and delivers notifications in the order they were
+// received, without holding up the connection's decode loop: handleSubscription
+// only has to push onto the buffered raw channel, not wait on the, possibly
+// slow, consumer of ch.
+func (s *ClientSubscription) pump() {
+	defer close(s.stopped)
+
+	elemType := s.ch.Type().Elem()
+	doneCase := reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.done)}
+
+	for {
+		select {
+		case raw := <-s.raw:
+			v := reflect.New(elemType)
+			if err := json.Unmarshal(raw, v.Interface()); err != nil {
+				continue
+			}
+
+			sendCase := reflect.SelectCase{Dir: reflect.SelectSend, Chan: s.ch, Send: v.Elem()}
+			if chosen, _, _ := reflect.Select([]reflect.SelectCase{sendCase, doneCase}); chosen == 1 {
+				return
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Unsubscribe stops delivering further notifications, closes ch, and sends
+// the standard unsubscribe notification to the server.
+func (s *ClientSubscription) Unsubscribe() error {
+	s.close()
+
+	return s.c.writeJSON(clientRequest{
+		Method: unsubscribeMethod,
+		Params: unsubscribeParams{ID: s.id},
+	})
+}
+
+// Err returns a channel that receives a single value, and is then closed, if
+// the connection is lost before Unsubscribe is called.
+func (s *ClientSubscription) Err() <-chan error {
+	return s.errC
+}
+
+// close stops pump and, once it has genuinely exited and so can no longer be
+// attempting a send on ch, closes ch. Waiting for pump to stop first avoids
+// racing a send against the close.
+func (s *ClientSubscription) close() {
+	s.closeOnce.Do(func() {
+		s.c.mu.Lock()
+		delete(s.c.subs, s.id)
+		s.c.mu.Unlock()
+
+		close(s.done)
+		<-s.stopped
+		s.ch.Close()
+	})
+}
+
+func (c *clientHandler) handleSubscription(raw json.RawMessage) {
+	var params struct {
+		Subscription string          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	}
+
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	sub, ok := c.subs[params.Subscription]
+	c.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	select {
+	case sub.raw <- params.Result:
+	case <-sub.done:
+	}
+}
+
+// teardownSubs stops every active subscription, notifying each of err via
+// Err, e.g. when the underlying connection is closed or lost.
+func (c *clientHandler) teardownSubs(err error) {
+	c.mu.Lock()
+	subs := c.subs
+	c.subs = nil
+	c.mu.Unlock()
+
+	for _, sub := range subs {
+		select {
+		case sub.errC <- err:
+		default:
+		}
+		close(sub.errC)
+
+		sub.close()
+	}
+}
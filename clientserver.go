@@ -1,7 +1,10 @@
 package jsonrpc
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 )
@@ -10,34 +13,68 @@ import (
 type ClientServer struct {
 	serverHandler
 	clientHandler
-	decoder *json.Decoder
+	stream Stream
 }
 
 // NewClientServer creates both client and server handling on the same
-// connection.
-func NewClientServer(conn io.ReadWriter, handler Handler) *ClientServer {
-	closer, ok := conn.(io.Closer)
-	if !ok {
-		closer = io.NopCloser(conn)
-	}
+// connection, treating conn as a newline-delimited JSON stream. It is a
+// thin wrapper over NewClientServerFromStream and NewStdioStream.
+//
+// handler must implement Handler or HandlerContext.
+func NewClientServer(conn io.ReadWriter, handler any, opts ...Option) *ClientServer {
+	return NewClientServerFromStream(NewStdioStream(conn), handler, opts...)
+}
+
+// NewClientServerFromStream creates both client and server handling over
+// the same stream.
+//
+// handler must implement Handler or HandlerContext; NewClientServerFromStream
+// panics otherwise.
+//
+// Locally issued calls, made with Request or RequestContext, are numbered
+// with positive integers starting at 1; Notify sends no id at all. The peer
+// may number its own requests from the same range: a message is a response
+// to one of ours only if it carries no method, in which case its id is
+// looked up in our own table, never reinterpreted as one the peer chose.
+func NewClientServerFromStream(stream Stream, handler any, opts ...Option) *ClientServer {
+	checkHandler(handler)
+
+	o := applyOptions(opts)
 
 	cs := &ClientServer{
 		serverHandler: serverHandler{
 			handler: handler,
-			writer:  conn,
+			stream:  stream,
+			strict:  o.strict,
 		},
 		clientHandler: clientHandler{
-			closer:   closer,
-			encoder:  json.NewEncoder(conn),
-			decoder:  json.NewDecoder(conn),
+			stream:   stream,
+			strict:   o.strict,
+			nextID:   1,
 			requests: make(map[int]chan clientResponse),
 			waits:    make(map[int]*wait),
 		},
+		stream: stream,
 	}
+	cs.notifier = newNotifier(&cs.serverHandler)
+	cs.serverHandler.peer = cs
 
 	return cs
 }
 
+type peerKey struct{}
+
+// PeerFromContext returns the ClientServer a handler is being invoked over,
+// letting it call back into the very peer that made this request - e.g. to
+// Notify it, or to make a new Request of its own - over the same
+// connection. It returns nil if the handler wasn't invoked by a
+// ClientServer.
+func PeerFromContext(ctx context.Context) *ClientServer {
+	p, _ := ctx.Value(peerKey{}).(*ClientServer)
+
+	return p
+}
+
 type requestOrResponse struct {
 	request
 	Result json.RawMessage `json:"result"`
@@ -48,27 +85,62 @@ type requestOrResponse struct {
 // handle client responses.
 //
 // The func will return only when it encounters a read error, be it from a
-// closed connection, or from some fault on the wire.
+// closed connection, or from some fault on the wire. A malformed message,
+// such as invalid JSON, gets a ParseError response before Handle returns:
+// encoding/json's Decoder cannot resynchronize to a later value once it has
+// rejected one, so the connection is ended rather than spun on the same
+// decode error forever. Any subscriptions created over the connection, in
+// either direction, are torn down before it returns.
 func (c *ClientServer) Handle() error {
+	defer c.serverHandler.notifier.teardown()
+
 	for {
-		var req requestOrResponse
+		raw, err := c.stream.Read()
+		if err != nil {
+			var malformed *malformedMessageError
+			if errors.As(err, &malformed) {
+				c.serverHandler.send(jsonNil, nil, ParseError)
+			}
+
+			c.clientHandler.teardownSubs(err)
 
-		if err := c.decoder.Decode(&req); err != nil {
-			return fmt.Errorf("error decoding JSON request: %w", err)
+			return fmt.Errorf("error reading from stream: %w", err)
 		}
 
-		if req.Method != "" {
-			go c.serverHandler.handleRequest(req.request)
-		} else {
-			var id int
+		go c.handleRaw(raw)
+	}
+}
+
+// handleRaw dispatches a single decoded JSON value, which may be a request, a
+// response to a call this peer made, a subscription notification, or, in
+// Strict mode, a batch of requests.
+func (c *ClientServer) handleRaw(raw json.RawMessage) {
+	trimmed := bytes.TrimSpace(raw)
+	if c.serverHandler.strict && len(trimmed) > 0 && trimmed[0] == '[' {
+		c.serverHandler.handleBatch(trimmed)
+		return
+	}
 
-			json.Unmarshal(req.ID, &id)
+	var req requestOrResponse
+	if err := json.Unmarshal(raw, &req); err != nil {
+		c.serverHandler.send(jsonNil, nil, ParseError)
+		return
+	}
 
-			go c.clientHandler.handleResponse(clientResponse{
-				ID:     id,
-				Result: req.Result,
-				Error:  req.Error,
-			})
-		}
+	switch {
+	case req.Method == subscriptionMethod:
+		c.clientHandler.handleSubscription(req.Params)
+	case req.Method != "":
+		c.serverHandler.handleRequest(req.request)
+	default:
+		var id int
+
+		json.Unmarshal(req.ID, &id)
+
+		c.clientHandler.handleResponse(clientResponse{
+			ID:     id,
+			Result: req.Result,
+			Error:  req.Error,
+		})
 	}
 }
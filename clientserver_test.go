@@ -0,0 +1,138 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClientServerNotify(t *testing.T) {
+	t.Parallel()
+	aConn, bConn := makeServerClientConn()
+
+	received := make(chan struct{}, 1)
+	handlerA := HandlerFunc(func(method string, data json.RawMessage) (any, error) {
+		if method == "ping" {
+			received <- struct{}{}
+		}
+
+		return nil, nil
+	})
+
+	a := NewClientServer(aConn, handlerA)
+	go a.Handle()
+	defer aConn.Close()
+
+	b := NewClientServer(bConn, HandlerFunc(func(string, json.RawMessage) (any, error) {
+		return nil, nil
+	}))
+	go b.Handle()
+	defer bConn.Close()
+
+	if err := b.Notify("ping", nil); err != nil {
+		t.Fatalf("unexpected error notifying: %s", err)
+	}
+
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for notification")
+	}
+}
+
+func doublingHandler() Handler {
+	return HandlerFunc(func(method string, data json.RawMessage) (any, error) {
+		var n int
+		if err := json.Unmarshal(data, &n); err != nil {
+			return nil, err
+		}
+
+		return n * 2, nil
+	})
+}
+
+// TestClientServerBidirectional has both peers call each other's "double"
+// method at the same time, checking that each side's response is matched
+// back up to the call that asked for it, not the other side's.
+func TestClientServerBidirectional(t *testing.T) {
+	t.Parallel()
+	aConn, bConn := makeServerClientConn()
+
+	a := NewClientServer(aConn, doublingHandler())
+	go a.Handle()
+	defer aConn.Close()
+
+	b := NewClientServer(bConn, doublingHandler())
+	go b.Handle()
+	defer bConn.Close()
+
+	var (
+		wg               sync.WaitGroup
+		aResult, bResult int
+		aErr, bErr       error
+	)
+
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		aErr = a.RequestValue("double", 5, &aResult)
+	}()
+	go func() {
+		defer wg.Done()
+		bErr = b.RequestValue("double", 3, &bResult)
+	}()
+
+	wg.Wait()
+
+	if aErr != nil {
+		t.Errorf("unexpected error calling from a: %s", aErr)
+	} else if aResult != 10 {
+		t.Errorf("expecting a's result to be 10, got %d", aResult)
+	}
+
+	if bErr != nil {
+		t.Errorf("unexpected error calling from b: %s", bErr)
+	} else if bResult != 6 {
+		t.Errorf("expecting b's result to be 6, got %d", bResult)
+	}
+}
+
+func TestPeerFromContext(t *testing.T) {
+	t.Parallel()
+	aConn, bConn := makeServerClientConn()
+
+	relay := HandlerContextFunc(func(ctx context.Context, method string, data json.RawMessage) (any, error) {
+		peer := PeerFromContext(ctx)
+		if peer == nil {
+			return nil, errors.New("expecting a peer in context")
+		}
+
+		var result int
+		if err := peer.RequestValue("double", data, &result); err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	})
+
+	a := NewClientServer(aConn, relay)
+	go a.Handle()
+	defer aConn.Close()
+
+	b := NewClientServer(bConn, doublingHandler())
+	go b.Handle()
+	defer bConn.Close()
+
+	var result int
+	if err := b.RequestValue("relay", 4, &result); err != nil {
+		t.Fatalf("unexpected error requesting: %s", err)
+	}
+
+	if result != 8 {
+		t.Errorf("expecting 8, got %d", result)
+	}
+}
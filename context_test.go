@@ -0,0 +1,72 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRequestContextCancel(t *testing.T) {
+	t.Parallel()
+	serverConn, clientConn := makeServerClientConn()
+
+	cancelled := make(chan struct{})
+	handler := HandlerContextFunc(func(ctx context.Context, method string, data json.RawMessage) (any, error) {
+		<-ctx.Done()
+		close(cancelled)
+
+		return nil, ctx.Err()
+	})
+
+	s := New(serverConn, handler)
+	go s.Handle()
+	defer serverConn.Close()
+
+	c := NewClient(clientConn)
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	if _, err := c.RequestContext(ctx, "slow", nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expecting context.Canceled, got %s", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("expecting the handler's context to be cancelled by the cancel frame")
+	}
+}
+
+func TestRequestContextNoLeak(t *testing.T) {
+	t.Parallel()
+	serverConn, clientConn := makeServerClientConn()
+
+	s := New(serverConn, new(simpleHandler))
+	go s.Handle()
+	defer serverConn.Close()
+
+	c := NewClient(clientConn)
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.RequestContext(ctx, "add", [2]int{1, 2}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expecting context.Canceled, got %s", err)
+	}
+
+	c.mu.Lock()
+	_, ok := c.requests[1]
+	c.mu.Unlock()
+
+	if ok {
+		t.Error("expecting the pending request to be removed after cancellation")
+	}
+}
@@ -0,0 +1,257 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ErrNoServerPush is returned when something attempts a server-initiated
+// write outside of answering the request that's currently being handled —
+// a legacy Await/Subscribe push, a Notifier.Notify, or a second Send — over
+// a transport, such as HTTP, that has no open connection to deliver it on.
+var ErrNoServerPush = errors.New("jsonrpc: transport has no server push")
+
+// httpResponseStream is the Stream for a single HTTP request: its one
+// permitted Write becomes the HTTP response body, after which, and for any
+// would-be push, it reports ErrNoServerPush instead.
+type httpResponseStream struct {
+	body []byte
+	read bool
+
+	w http.ResponseWriter
+
+	mu    sync.Mutex
+	wrote bool
+}
+
+func (s *httpResponseStream) Read() ([]byte, error) {
+	if s.read {
+		return nil, io.EOF
+	}
+	s.read = true
+
+	return s.body, nil
+}
+
+func (s *httpResponseStream) Write(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.wrote {
+		return ErrNoServerPush
+	}
+	s.wrote = true
+
+	s.w.Header().Set("Content-Type", "application/json")
+
+	_, err := s.w.Write(data)
+
+	return err
+}
+
+func (s *httpResponseStream) Close() error {
+	return nil
+}
+
+// finish completes the HTTP response for a request that produced no
+// write at all, i.e. one that was, or contained only, notifications.
+func (s *httpResponseStream) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.wrote {
+		s.w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// NewHTTPHandler returns a http.Handler that treats each POST body as a
+// single request, or, in Strict mode, a single batch of requests, and
+// writes a single JSON response.
+//
+// handler must implement Handler or HandlerContext; NewHTTPHandler panics
+// otherwise.
+//
+// Because a HTTP response only ever answers the request that produced it,
+// a handler invoked this way that attempts a server-initiated push — via
+// the legacy Await/Subscribe pattern, or via Notifier.Notify — receives
+// ErrNoServerPush instead of delivering it.
+func NewHTTPHandler(handler any, opts ...Option) http.Handler {
+	checkHandler(handler)
+
+	o := applyOptions(opts)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+
+		r.Body.Close()
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		stream := &httpResponseStream{body: body, w: w}
+
+		sh := &serverHandler{
+			handler: handler,
+			stream:  stream,
+			strict:  o.strict,
+		}
+		// Notifications go through a stream whose one Write is already
+		// spent, so Notify fails with ErrNoServerPush instead of taking the
+		// place of the response body.
+		sh.notifier = newNotifier(&serverHandler{
+			stream: &httpResponseStream{wrote: true},
+			strict: o.strict,
+		})
+
+		defer sh.notifier.teardown()
+
+		sh.handleRaw(body)
+		stream.finish()
+	})
+}
+
+// HTTPClient is a RPC client that issues each request as its own HTTP POST,
+// reusing the given http.Client's connections via keep-alive. Because a
+// HTTP response only ever answers the request that produced it, Await,
+// Subscribe, and SubscribeContext are not supported and return
+// ErrNoServerPush.
+type HTTPClient struct {
+	url    string
+	client *http.Client
+	strict bool
+
+	mu     sync.Mutex
+	nextID int
+}
+
+// NewHTTPClient creates a HTTPClient posting requests to url. If client is
+// nil, http.DefaultClient is used.
+func NewHTTPClient(url string, client *http.Client, opts ...Option) *HTTPClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	o := applyOptions(opts)
+
+	return &HTTPClient{
+		url:    url,
+		client: client,
+		strict: o.strict,
+	}
+}
+
+func (c *HTTPClient) id() int {
+	c.mu.Lock()
+	id := c.nextID
+	c.nextID++
+	c.mu.Unlock()
+
+	return id
+}
+
+// Request makes an RPC call to the server as its own HTTP POST, returning
+// the JSON encoded response, or an error.
+func (c *HTTPClient) Request(method string, params any) (json.RawMessage, error) {
+	return c.RequestContext(context.Background(), method, params)
+}
+
+// RequestContext acts as Request, but aborts the HTTP round trip if ctx is
+// cancelled before the response arrives.
+func (c *HTTPClient) RequestContext(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	req := clientRequest{
+		ID:     c.id(),
+		Method: method,
+		Params: params,
+	}
+	if c.strict {
+		req.JSONRPC = Version
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp clientResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	return resp.Result, nil
+}
+
+// RequestValue acts as Request, but will unmarshal the response into the
+// given value.
+func (c *HTTPClient) RequestValue(method string, params any, response any) error {
+	data, err := c.Request(method, params)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, response)
+}
+
+// RequestValueContext acts as RequestContext, but will unmarshal the
+// response into the given value.
+func (c *HTTPClient) RequestValueContext(ctx context.Context, method string, params any, response any) error {
+	data, err := c.RequestContext(ctx, method, params)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, response)
+}
+
+// Await always returns ErrNoServerPush: HTTP has no connection left open
+// for the server to push a later message onto.
+func (c *HTTPClient) Await(id int, cb func(json.RawMessage)) error {
+	return ErrNoServerPush
+}
+
+// Subscribe always returns ErrNoServerPush, for the same reason as Await.
+func (c *HTTPClient) Subscribe(id int, cb func(json.RawMessage)) error {
+	return ErrNoServerPush
+}
+
+// SubscribeContext always returns ErrNoServerPush, for the same reason as
+// Await.
+func (c *HTTPClient) SubscribeContext(ctx context.Context, method string, args any, ch any) (*ClientSubscription, error) {
+	return nil, ErrNoServerPush
+}
@@ -0,0 +1,99 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrUnknownSubscription is returned by Notifier.Notify when sub is no
+// longer active, e.g. because the client unsubscribed or the connection
+// closed.
+var ErrUnknownSubscription = errors.New("unknown subscription")
+
+type notifierKey struct{}
+
+// NotifierFromContext returns the Notifier for the connection a handler is
+// being invoked over, or nil if ctx wasn't passed to a HandlerContext by a
+// Server, a ClientServer, or the http.Handler returned by NewHTTPHandler.
+func NotifierFromContext(ctx context.Context) *Notifier {
+	n, _ := ctx.Value(notifierKey{}).(*Notifier)
+
+	return n
+}
+
+// Notifier lets a HandlerContext create subscriptions and push data to
+// subscribed clients for as long as the underlying connection stays open.
+// One Notifier exists per connection; retrieve it with NotifierFromContext.
+type Notifier struct {
+	sh      *serverHandler
+	counter uint64
+
+	mu   sync.Mutex
+	subs map[string]struct{}
+}
+
+func newNotifier(sh *serverHandler) *Notifier {
+	return &Notifier{
+		sh:   sh,
+		subs: make(map[string]struct{}),
+	}
+}
+
+// Subscription is a server-allocated, opaque subscription id, created with
+// Notifier.CreateSubscription and passed to Notifier.Notify.
+type Subscription struct {
+	ID string
+}
+
+// CreateSubscription allocates a new subscription. Its ID should be returned
+// as the result of the handler call that created it, so the client can
+// match subsequent notifications to it.
+func (n *Notifier) CreateSubscription() *Subscription {
+	id := strconv.FormatUint(atomic.AddUint64(&n.counter, 1), 36)
+
+	n.mu.Lock()
+	n.subs[id] = struct{}{}
+	n.mu.Unlock()
+
+	return &Subscription{ID: id}
+}
+
+// Notify pushes payload to the client as a notification naming sub.
+func (n *Notifier) Notify(sub *Subscription, payload any) error {
+	n.mu.Lock()
+	_, ok := n.subs[sub.ID]
+	n.mu.Unlock()
+
+	if !ok {
+		return ErrUnknownSubscription
+	}
+
+	msg := subscriptionNotice{
+		Method: subscriptionMethod,
+		Params: subscriptionParams{
+			Subscription: sub.ID,
+			Result:       payload,
+		},
+	}
+	if n.sh.strict {
+		msg.JSONRPC = Version
+	}
+
+	return n.sh.writeJSON(msg)
+}
+
+func (n *Notifier) unsubscribe(id string) {
+	n.mu.Lock()
+	delete(n.subs, id)
+	n.mu.Unlock()
+}
+
+// teardown removes every subscription, e.g. when the connection closes.
+func (n *Notifier) teardown() {
+	n.mu.Lock()
+	n.subs = make(map[string]struct{})
+	n.mu.Unlock()
+}
@@ -2,23 +2,35 @@
 package jsonrpc // import "vimagination.zapto.org/jsonrpc"
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"reflect"
 )
 
+// Version is the value of the "jsonrpc" member sent on the wire when a
+// connection is running in Strict mode.
+const Version = "2.0"
+
 type request struct {
-	Method string          `json:"method"`
-	Params json.RawMessage `json:"params"`
-	ID     json.RawMessage `json:"id"`
+	JSONRPC string          `json:"jsonrpc,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// isNotification reports whether the request carries no id and so expects no
+// response, per the JSON-RPC 2.0 notification rules.
+func (r *request) isNotification() bool {
+	return len(r.ID) == 0
 }
 
 // Response represents a response to a client
 type Response struct {
-	ID     int    `json:"id"`
-	Result any    `json:"result,omitempty"`
-	Error  *Error `json:"error,omitempty"`
+	JSONRPC string `json:"jsonrpc,omitempty"`
+	ID      any    `json:"id"`
+	Result  any    `json:"result,omitempty"`
+	Error   *Error `json:"error,omitempty"`
 }
 
 // Error represents the error type for RPC requests
@@ -50,6 +62,17 @@ func (e Error) Error() string {
 	return e.Message
 }
 
+// Standard JSON-RPC 2.0 errors, as defined by the specification. ParseError
+// and InvalidRequest are returned by the dispatch path itself; the rest are
+// provided for Handler implementations to return as needed.
+var (
+	ParseError     = &Error{Code: -32700, Message: "parse error"}
+	InvalidRequest = &Error{Code: -32600, Message: "invalid request"}
+	MethodNotFound = &Error{Code: -32601, Message: "method not found"}
+	InvalidParams  = &Error{Code: -32602, Message: "invalid params"}
+	InternalError  = &Error{Code: -32603, Message: "internal error"}
+)
+
 // Handler takes a method name and a JSON Raw Message byte slice and should
 // return data OR an error, not both
 type Handler interface {
@@ -65,99 +88,100 @@ func (r HandlerFunc) HandleRPC(method string, data json.RawMessage) (any, error)
 	return r(method, data)
 }
 
-// Server represents a RPC server connection that will handle responses from a
-// single client
-type Server struct {
-	handler Handler
-	decoder *json.Decoder
+// HandlerContext is an optional extension of Handler for implementations
+// that want to observe cancellation. When a Server or ClientServer receives
+// it, it is used in place of Handler, and the ctx passed to HandleRPC is
+// cancelled when the peer sends a companion cancelMethod frame naming this
+// request's id.
+type HandlerContext interface {
+	HandleRPC(ctx context.Context, method string, data json.RawMessage) (any, error)
+}
 
-	encoder *json.Encoder
-	writer  io.Writer
+// HandlerContextFunc is a convenience type to wrap a function for the
+// HandlerContext interface.
+type HandlerContextFunc func(context.Context, string, json.RawMessage) (any, error)
+
+// HandleRPC implements the HandlerContext inteface
+func (r HandlerContextFunc) HandleRPC(ctx context.Context, method string, data json.RawMessage) (any, error) {
+	return r(ctx, method, data)
 }
 
-// New creates a new Server connection
-func New(conn io.ReadWriter, handler Handler) *Server {
-	return &Server{
-		handler: handler,
-		decoder: json.NewDecoder(conn),
-		encoder: json.NewEncoder(conn),
-		writer:  conn,
+// checkHandler panics unless handler implements Handler or HandlerContext.
+// New, NewFromStream, NewClientServer, and NewClientServerFromStream all
+// accept handler as any, since the two interfaces share a method name but
+// not a signature, and so can't be expressed as a single static type; this
+// is what stands in for the compiler catching the mistake.
+func checkHandler(handler any) {
+	switch handler.(type) {
+	case Handler, HandlerContext:
+	default:
+		panic(fmt.Sprintf("jsonrpc: %T implements neither Handler nor HandlerContext", handler))
 	}
 }
 
-// Handle starts the server's handling loop.
-//
-// The func will return only when it encounters a read error, be it from a
-// closed connection, or from some fault on the wire.
-func (s *Server) Handle() error {
-	for {
-		var req request
-		if err := s.decoder.Decode(&req); err != nil {
-			return fmt.Errorf("error decoding JSON request: %w", err)
-		}
-		go s.handleRequest(req)
-	}
+// cancelMethod is the method name of the companion cancellation
+// notification sent to abort a previously issued, still in-flight request.
+const cancelMethod = "$/cancelRequest"
+
+// cancelParams carries the id of the request being cancelled exactly as it
+// appeared on the wire, so it can name a request keyed by any id shape -
+// string, number, or null - not just the positive integers this package's
+// own Client allocates for its own calls.
+type cancelParams struct {
+	ID json.RawMessage `json:"id"`
+}
+
+// unsubscribeMethod is the method name of the companion notification a
+// client sends to tear down a subscription it created.
+const unsubscribeMethod = "$/unsubscribe"
+
+type unsubscribeParams struct {
+	ID string `json:"id"`
 }
 
-func (s *Server) handleRequest(req request) error {
-	result, err := s.handler.HandleRPC(req.Method, req.Params)
-	return s.send(req.ID, result, err)
+// subscriptionMethod is the method name used for server-to-client messages
+// carrying subscription data.
+const subscriptionMethod = "subscription"
+
+type subscriptionNotice struct {
+	JSONRPC string             `json:"jsonrpc,omitempty"`
+	Method  string             `json:"method"`
+	Params  subscriptionParams `json:"params"`
 }
 
-// Send sends the encoded Response to the client
-func (s *Server) Send(resp Response) error {
-	return s.encoder.Encode(resp)
+type subscriptionParams struct {
+	Subscription string `json:"subscription"`
+	Result       any    `json:"result"`
 }
 
-const (
-	jsonHead = "{\"id\":"
-	jsonMid  = ",\"result\":"
-	jsonErr  = ",\"error\":"
-	jsonTail = '}'
-)
+// Option configures optional, opt-in behaviour of a Server, Client, or
+// ClientServer.
+type Option func(*options)
 
-var jsonNil = json.RawMessage{'n', 'u', 'l', 'l'}
-
-func (s *Server) send(id json.RawMessage, data any, e error) error {
-	var (
-		err error
-		rm  json.RawMessage
-		ok  bool
-	)
-	mid := jsonMid
-	if e != nil {
-		if errr, ok := e.(*Error); ok {
-			rm, err = json.Marshal(errr)
-		} else {
-			rm, err = json.Marshal(Error{
-				Message: e.Error(),
-				Data:    e,
-			})
-		}
-		mid = jsonErr
-	} else if data == nil {
-		rm = jsonNil
-	} else {
-		rm, ok = data.(json.RawMessage)
-		if !ok {
-			rm, err = json.Marshal(data)
-		} else if len(rm) == 0 {
-			rm = jsonNil
-		}
-	}
-	if err != nil {
-		return fmt.Errorf("error marshaling JSON: %w", err)
-	}
-	if _, err = s.writer.Write(append(append(append(append(append(make([]byte, 0, len(jsonHead)+len(id)+len(mid)+len(rm)+1), jsonHead...), id...), mid...), rm...), jsonTail)); err != nil {
-		return fmt.Errorf("error writing to socket: %w", err)
+type options struct {
+	strict bool
+}
+
+// Strict enables JSON-RPC 2.0 wire compliance: the "jsonrpc":"2.0" envelope
+// is sent with every message, and batches (a top-level JSON array of
+// requests) are accepted and dispatched concurrently. Requests sent without
+// an id are treated as notifications that receive no response in either
+// mode.
+//
+// Without this option a connection otherwise keeps using the package's
+// original, simpler wire format.
+func Strict() Option {
+	return func(o *options) {
+		o.strict = true
 	}
-	return nil
 }
 
-// SendData sends the raw bytes (unencoded) to the client
-func (s *Server) SendData(data json.RawMessage) error {
-	if _, err := s.writer.Write(data); err != nil {
-		return fmt.Errorf("error sending data: %w", err)
+func applyOptions(opts []Option) options {
+	var o options
+
+	for _, opt := range opts {
+		opt(&o)
 	}
-	return nil
+
+	return o
 }
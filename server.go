@@ -0,0 +1,364 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// serverHandler implements the server half of the protocol: dispatching
+// decoded requests to a Handler or HandlerContext and writing the results
+// back onto the stream. It is embedded by both Server and ClientServer.
+type serverHandler struct {
+	handler any
+	stream  Stream
+
+	strict bool
+
+	notifier *Notifier
+	peer     *ClientServer
+
+	cancelMu sync.Mutex
+	cancels  map[string]context.CancelFunc
+}
+
+// Server represents a RPC server connection that will handle responses from a
+// single client
+type Server struct {
+	serverHandler
+}
+
+// New creates a new Server connection, treating conn as a newline-delimited
+// JSON stream. It is a thin wrapper over NewFromStream and NewStdioStream.
+//
+// handler must implement Handler or HandlerContext.
+func New(conn io.ReadWriter, handler any, opts ...Option) *Server {
+	return NewFromStream(NewStdioStream(conn), handler, opts...)
+}
+
+// NewFromStream creates a new Server reading requests from, and writing
+// responses to, stream.
+//
+// handler must implement Handler or HandlerContext; NewFromStream panics
+// otherwise.
+func NewFromStream(stream Stream, handler any, opts ...Option) *Server {
+	checkHandler(handler)
+
+	o := applyOptions(opts)
+
+	s := &Server{
+		serverHandler: serverHandler{
+			handler: handler,
+			stream:  stream,
+			strict:  o.strict,
+		},
+	}
+	s.notifier = newNotifier(&s.serverHandler)
+
+	return s
+}
+
+// Handle starts the server's handling loop.
+//
+// The func will return only when it encounters a read error, be it from a
+// closed connection, or from some fault on the wire. A malformed message,
+// such as invalid JSON, gets a ParseError response before Handle returns:
+// encoding/json's Decoder cannot resynchronize to a later value once it has
+// rejected one, so the connection is ended rather than spun on the same
+// decode error forever. Any subscriptions created over the connection are
+// torn down before it returns.
+func (s *Server) Handle() error {
+	defer s.notifier.teardown()
+
+	for {
+		raw, err := s.stream.Read()
+		if err != nil {
+			var malformed *malformedMessageError
+			if errors.As(err, &malformed) {
+				s.send(jsonNil, nil, ParseError)
+			}
+
+			return fmt.Errorf("error reading from stream: %w", err)
+		}
+
+		go s.handleRaw(raw)
+	}
+}
+
+// handleRaw dispatches a single decoded JSON value, which may be either a
+// request object or, in Strict mode, a batch of requests.
+func (s *serverHandler) handleRaw(raw json.RawMessage) {
+	trimmed := bytes.TrimSpace(raw)
+	if s.strict && len(trimmed) > 0 && trimmed[0] == '[' {
+		s.handleBatch(trimmed)
+		return
+	}
+
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		s.send(jsonNil, nil, ParseError)
+		return
+	}
+
+	s.handleRequest(req)
+}
+
+func (s *serverHandler) handleRequest(req request) {
+	result, err, respond := s.process(req)
+	if !respond {
+		return
+	}
+
+	s.send(req.ID, result, err)
+}
+
+// process dispatches req to the handler, tracking its context's cancel func
+// so a matching cancelMethod notification can abort it, and reports whether
+// req expects a response at all.
+func (s *serverHandler) process(req request) (result any, err error, respond bool) {
+	if req.Method == cancelMethod {
+		s.handleCancel(req)
+		return nil, nil, false
+	}
+
+	if req.Method == unsubscribeMethod {
+		s.handleUnsubscribe(req)
+		return nil, nil, false
+	}
+
+	if req.Method == "" {
+		return nil, InvalidRequest, !req.isNotification()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctx = context.WithValue(ctx, notifierKey{}, s.notifier)
+
+	if s.peer != nil {
+		ctx = context.WithValue(ctx, peerKey{}, s.peer)
+	}
+
+	if !req.isNotification() {
+		id := string(req.ID)
+		s.trackCancel(id, cancel)
+		defer s.untrackCancel(id)
+	}
+
+	result, err = s.dispatch(ctx, req.Method, req.Params)
+
+	return result, err, !req.isNotification()
+}
+
+func (s *serverHandler) dispatch(ctx context.Context, method string, params json.RawMessage) (any, error) {
+	if hc, ok := s.handler.(HandlerContext); ok {
+		return hc.HandleRPC(ctx, method, params)
+	}
+
+	return s.handler.(Handler).HandleRPC(method, params)
+}
+
+func (s *serverHandler) handleCancel(req request) {
+	var params cancelParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return
+	}
+
+	s.cancelMu.Lock()
+	cancel, ok := s.cancels[string(params.ID)]
+	s.cancelMu.Unlock()
+
+	if ok {
+		cancel()
+	}
+}
+
+func (s *serverHandler) handleUnsubscribe(req request) {
+	var params unsubscribeParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return
+	}
+
+	s.notifier.unsubscribe(params.ID)
+}
+
+func (s *serverHandler) trackCancel(id string, cancel context.CancelFunc) {
+	s.cancelMu.Lock()
+
+	if s.cancels == nil {
+		s.cancels = make(map[string]context.CancelFunc)
+	}
+	s.cancels[id] = cancel
+
+	s.cancelMu.Unlock()
+}
+
+func (s *serverHandler) untrackCancel(id string) {
+	s.cancelMu.Lock()
+	delete(s.cancels, id)
+	s.cancelMu.Unlock()
+}
+
+// handleBatch dispatches every request in a batch concurrently and combines
+// their responses, if any, into a single response array. A batch containing
+// only notifications, as well as an empty batch, produces no response.
+func (s *serverHandler) handleBatch(raw json.RawMessage) {
+	var reqs []request
+	if err := json.Unmarshal(raw, &reqs); err != nil {
+		s.send(jsonNil, nil, ParseError)
+		return
+	}
+
+	if len(reqs) == 0 {
+		return
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		resp []json.RawMessage
+	)
+
+	wg.Add(len(reqs))
+
+	for _, req := range reqs {
+		go func(req request) {
+			defer wg.Done()
+
+			result, err, respond := s.process(req)
+			if !respond {
+				return
+			}
+
+			rm, buildErr := s.buildResponse(req.ID, result, err)
+			if buildErr != nil {
+				return
+			}
+
+			mu.Lock()
+			resp = append(resp, rm)
+			mu.Unlock()
+		}(req)
+	}
+
+	wg.Wait()
+
+	if len(resp) == 0 {
+		return
+	}
+
+	s.stream.Write(joinBatch(resp))
+}
+
+// joinBatch combines individually built response bodies into a single JSON
+// array, e.g. [{"id":1,...},{"id":2,...}].
+func joinBatch(resp []json.RawMessage) []byte {
+	buf := make([]byte, 0, len(resp)+1)
+	buf = append(buf, '[')
+
+	for n, rm := range resp {
+		if n > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, rm...)
+	}
+
+	return append(buf, ']')
+}
+
+// Send sends the encoded Response to the client
+func (s *serverHandler) Send(resp Response) error {
+	if s.strict {
+		resp.JSONRPC = Version
+	}
+
+	return s.writeJSON(resp)
+}
+
+// writeJSON marshals v and writes it to the stream as a single message.
+func (s *serverHandler) writeJSON(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error marshaling JSON: %w", err)
+	}
+
+	return s.stream.Write(data)
+}
+
+const (
+	jsonHeadLegacy = "{\"id\":"
+	jsonHeadStrict = "{\"jsonrpc\":\"2.0\",\"id\":"
+	jsonMid        = ",\"result\":"
+	jsonErr        = ",\"error\":"
+	jsonTail       = '}'
+)
+
+var jsonNil = json.RawMessage{'n', 'u', 'l', 'l'}
+
+func (s *serverHandler) buildResponse(id json.RawMessage, data any, e error) (json.RawMessage, error) {
+	var (
+		err error
+		rm  json.RawMessage
+		ok  bool
+	)
+	mid := jsonMid
+	if e != nil {
+		if errr, ok := e.(*Error); ok {
+			rm, err = json.Marshal(errr)
+		} else {
+			rm, err = json.Marshal(Error{
+				Message: e.Error(),
+				Data:    e,
+			})
+		}
+		mid = jsonErr
+	} else if data == nil {
+		rm = jsonNil
+	} else {
+		rm, ok = data.(json.RawMessage)
+		if !ok {
+			rm, err = json.Marshal(data)
+		} else if len(rm) == 0 {
+			rm = jsonNil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling JSON: %w", err)
+	}
+
+	head := jsonHeadLegacy
+	if s.strict {
+		head = jsonHeadStrict
+	}
+
+	if len(id) == 0 {
+		id = jsonNil
+	}
+
+	return append(append(append(append(append(make([]byte, 0, len(head)+len(id)+len(mid)+len(rm)+1), head...), id...), mid...), rm...), jsonTail), nil
+}
+
+func (s *serverHandler) send(id json.RawMessage, data any, e error) error {
+	rm, err := s.buildResponse(id, data, e)
+	if err != nil {
+		return err
+	}
+
+	if err := s.stream.Write(rm); err != nil {
+		return fmt.Errorf("error writing to stream: %w", err)
+	}
+
+	return nil
+}
+
+// SendData sends the raw bytes (unencoded) to the client
+func (s *serverHandler) SendData(data json.RawMessage) error {
+	if err := s.stream.Write(data); err != nil {
+		return fmt.Errorf("error sending data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestStrictEnvelope(t *testing.T) {
+	t.Parallel()
+	serverConn, clientConn := makeServerClientConn()
+
+	s := New(serverConn, new(simpleHandler), Strict())
+	go s.Handle()
+	defer serverConn.Close()
+
+	if _, err := clientConn.Write([]byte(`{"jsonrpc":"2.0","method":"add","params":[5,6],"id":1}`)); err != nil {
+		t.Fatalf("unexpected write error: %s", err)
+	}
+
+	buf := make([]byte, 256)
+	n, err := clientConn.Read(buf)
+	if err != nil {
+		t.Fatalf("unexpected read error: %s", err)
+	}
+
+	if want := "{\"jsonrpc\":\"2.0\",\"id\":1,\"result\":11}\n"; string(buf[:n]) != want {
+		t.Errorf("expecting response %q, got %q", want, buf[:n])
+	}
+}
+
+func TestStrictNotification(t *testing.T) {
+	t.Parallel()
+	serverConn, clientConn := makeServerClientConn()
+
+	s := New(serverConn, new(simpleHandler), Strict())
+	go s.Handle()
+	defer serverConn.Close()
+
+	if _, err := clientConn.Write([]byte(`{"jsonrpc":"2.0","method":"add","params":[1,2]}`)); err != nil {
+		t.Fatalf("unexpected write error: %s", err)
+	}
+
+	if _, err := clientConn.Write([]byte(`{"jsonrpc":"2.0","method":"add","params":[5,6],"id":2}`)); err != nil {
+		t.Fatalf("unexpected write error: %s", err)
+	}
+
+	buf := make([]byte, 256)
+	n, err := clientConn.Read(buf)
+	if err != nil {
+		t.Fatalf("unexpected read error: %s", err)
+	}
+
+	if want := "{\"jsonrpc\":\"2.0\",\"id\":2,\"result\":11}\n"; string(buf[:n]) != want {
+		t.Errorf("expecting only the non-notification response %q, got %q", want, buf[:n])
+	}
+}
+
+func TestStrictBatch(t *testing.T) {
+	t.Parallel()
+	serverConn, clientConn := makeServerClientConn()
+
+	s := New(serverConn, new(simpleHandler), Strict())
+	go s.Handle()
+	defer serverConn.Close()
+
+	req := `[{"jsonrpc":"2.0","method":"add","params":[1,2],"id":1},{"jsonrpc":"2.0","method":"add","params":[3,4]},{"jsonrpc":"2.0","method":"add","params":[5,6],"id":2}]`
+	if _, err := clientConn.Write([]byte(req)); err != nil {
+		t.Fatalf("unexpected write error: %s", err)
+	}
+
+	buf := make([]byte, 256)
+	n, err := clientConn.Read(buf)
+	if err != nil {
+		t.Fatalf("unexpected read error: %s", err)
+	}
+
+	var resps []Response
+	if err := json.Unmarshal(buf[:n], &resps); err != nil {
+		t.Fatalf("invalid batch response %q: %s", buf[:n], err)
+	}
+
+	if len(resps) != 2 {
+		t.Fatalf("expecting 2 responses for the 2 non-notification requests, got %d", len(resps))
+	}
+}
+
+func TestStrictEmptyBatch(t *testing.T) {
+	t.Parallel()
+	serverConn, clientConn := makeServerClientConn()
+
+	s := New(serverConn, new(simpleHandler), Strict())
+	go s.Handle()
+	defer serverConn.Close()
+
+	if _, err := clientConn.Write([]byte(`[]`)); err != nil {
+		t.Fatalf("unexpected write error: %s", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		buf := make([]byte, 16)
+		clientConn.Read(buf)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Error("expecting no response for an empty batch")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestStrictParseError(t *testing.T) {
+	t.Parallel()
+	serverConn, clientConn := makeServerClientConn()
+
+	s := New(serverConn, new(simpleHandler), Strict())
+	go s.Handle()
+	defer serverConn.Close()
+
+	if _, err := clientConn.Write([]byte(`{not valid json`)); err != nil {
+		t.Fatalf("unexpected write error: %s", err)
+	}
+
+	buf := make([]byte, 256)
+	n, err := clientConn.Read(buf)
+	if err != nil {
+		t.Fatalf("unexpected read error: %s", err)
+	}
+
+	if !bytes.Contains(buf[:n], []byte(`"code":-32700`)) {
+		t.Errorf("expecting a ParseError response, got %q", buf[:n])
+	}
+}
@@ -0,0 +1,202 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+// ServiceSet implements HandlerContext by dispatching, via reflection, to
+// the exported methods of receivers registered with RegisterName - in the
+// manner of net/rpc.
+//
+// A method may take one of the following forms:
+//
+//	func(params T) (R, error)
+//	func(ctx context.Context, params T) (R, error)
+//	func() (R, error)
+//	func(ctx context.Context) (R, error)
+//
+// T is unmarshalled from the raw request params, which may be either a JSON
+// array (params are assigned to T's fields positionally) or a JSON object
+// (unmarshalled into T directly), and R is marshalled as the result.
+type ServiceSet struct {
+	sep string
+
+	mu      sync.RWMutex
+	methods map[string]method
+}
+
+type method struct {
+	fn        reflect.Value
+	paramType reflect.Type // nil for the zero-argument forms
+	takesCtx  bool
+}
+
+// NewServiceSet creates an empty ServiceSet. sep joins a RegisterName
+// namespace and a method name to form the registered RPC method name, e.g.
+// with the default sep of "_", registering Add under namespace "calc" is
+// called as "calc_Add"; pass "." for the "calc.Add" convention instead.
+func NewServiceSet(sep string) *ServiceSet {
+	if sep == "" {
+		sep = "_"
+	}
+
+	return &ServiceSet{
+		sep:     sep,
+		methods: make(map[string]method),
+	}
+}
+
+// RegisterName exposes every exported method of receiver that matches one of
+// ServiceSet's supported signatures under "namespace<sep>methodName". It
+// returns an error if receiver has no such methods.
+func (s *ServiceSet) RegisterName(namespace string, receiver any) error {
+	v := reflect.ValueOf(receiver)
+	t := v.Type()
+
+	registered := 0
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+
+		md, ok := parseMethod(v.Method(i).Type())
+		if !ok {
+			continue
+		}
+
+		md.fn = v.Method(i)
+		s.methods[namespace+s.sep+m.Name] = md
+		registered++
+	}
+
+	if registered == 0 {
+		return fmt.Errorf("jsonrpc: %T has no methods matching a supported signature", receiver)
+	}
+
+	return nil
+}
+
+// parseMethod determines whether t, the type of a bound method value,
+// matches one of ServiceSet's supported signatures, and if so precomputes
+// the reflection info needed to call it.
+func parseMethod(t reflect.Type) (method, bool) {
+	if t.NumOut() != 2 || t.Out(1) != errorType {
+		return method{}, false
+	}
+
+	var md method
+
+	switch t.NumIn() {
+	case 0:
+	case 1:
+		if t.In(0) == contextType {
+			md.takesCtx = true
+		} else {
+			md.paramType = t.In(0)
+		}
+	case 2:
+		if t.In(0) != contextType {
+			return method{}, false
+		}
+
+		md.takesCtx = true
+		md.paramType = t.In(1)
+	default:
+		return method{}, false
+	}
+
+	return md, true
+}
+
+// HandleRPC implements HandlerContext, looking method up in the
+// precomputed method cache - a map lookup plus, for methods taking params,
+// one allocation - and invoking it with the given ctx.
+func (s *ServiceSet) HandleRPC(ctx context.Context, method string, data json.RawMessage) (any, error) {
+	s.mu.RLock()
+	md, ok := s.methods[method]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, MethodNotFound
+	}
+
+	return md.call(ctx, data)
+}
+
+func (m method) call(ctx context.Context, data json.RawMessage) (any, error) {
+	in := make([]reflect.Value, 0, 2)
+
+	if m.takesCtx {
+		in = append(in, reflect.ValueOf(ctx))
+	}
+
+	if m.paramType != nil {
+		p := reflect.New(m.paramType)
+
+		if len(bytes.TrimSpace(data)) > 0 {
+			if err := unmarshalParams(data, p.Interface()); err != nil {
+				return nil, InvalidParams
+			}
+		}
+
+		in = append(in, p.Elem())
+	}
+
+	out := m.fn.Call(in)
+
+	if errv, _ := out[1].Interface().(error); errv != nil {
+		return nil, errv
+	}
+
+	return out[0].Interface(), nil
+}
+
+// unmarshalParams unmarshals a request's raw params into v, a pointer to a
+// freshly allocated param value. A JSON object is unmarshalled directly; a
+// JSON array is treated as positional arguments and assigned to v's fields,
+// in declaration order, or to v itself if it isn't a struct.
+func unmarshalParams(data json.RawMessage, v any) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return json.Unmarshal(data, v)
+	}
+
+	var args []json.RawMessage
+	if err := json.Unmarshal(data, &args); err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v).Elem()
+	if rv.Kind() != reflect.Struct {
+		if len(args) != 1 {
+			return fmt.Errorf("jsonrpc: %d positional params given for a single, non-struct param", len(args))
+		}
+
+		return json.Unmarshal(args[0], v)
+	}
+
+	for i := 0; i < rv.NumField() && i < len(args); i++ {
+		f := rv.Field(i)
+		if !f.CanSet() {
+			continue
+		}
+
+		if err := json.Unmarshal(args[i], f.Addr().Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
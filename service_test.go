@@ -0,0 +1,82 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type addParams struct {
+	A int
+	B int
+}
+
+type calcService struct{}
+
+func (calcService) Add(p addParams) (int, error) {
+	return p.A + p.B, nil
+}
+
+func (calcService) AddCtx(ctx context.Context, p addParams) (int, error) {
+	return p.A + p.B, nil
+}
+
+func (calcService) Ping() (string, error) {
+	return "pong", nil
+}
+
+func TestServiceSet(t *testing.T) {
+	ss := NewServiceSet("_")
+
+	if err := ss.RegisterName("calc", calcService{}); err != nil {
+		t.Fatalf("unexpected error registering calcService: %s", err)
+	}
+
+	for n, test := range [...]struct {
+		Method string
+		Params json.RawMessage
+		Result any
+		Error  error
+	}{
+		{
+			Method: "calc_Add",
+			Params: json.RawMessage(`[1,2]`),
+			Result: 3,
+		},
+		{
+			Method: "calc_Add",
+			Params: json.RawMessage(`{"A":4,"B":5}`),
+			Result: 9,
+		},
+		{
+			Method: "calc_AddCtx",
+			Params: json.RawMessage(`[1,2]`),
+			Result: 3,
+		},
+		{
+			Method: "calc_Ping",
+			Result: "pong",
+		},
+		{
+			Method: "calc_Unknown",
+			Error:  MethodNotFound,
+		},
+	} {
+		result, err := ss.HandleRPC(context.Background(), test.Method, test.Params)
+		if !errors.Is(test.Error, err) {
+			t.Errorf("test %d: expecting error %s, got %s", n+1, test.Error, err)
+		} else if !reflect.DeepEqual(test.Result, result) {
+			t.Errorf("test %d: expecting result %v, got %v", n+1, test.Result, result)
+		}
+	}
+}
+
+func TestServiceSetRegisterNameNoMatches(t *testing.T) {
+	ss := NewServiceSet("_")
+
+	if err := ss.RegisterName("nothing", struct{}{}); err == nil {
+		t.Error("expecting an error registering a receiver with no matching methods")
+	}
+}
@@ -0,0 +1,77 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Stream is the transport a Server, Client, or ClientServer reads requests
+// and responses from and writes them to. Each call to Read must return the
+// bytes of exactly one JSON value, and each call to Write must be given
+// exactly one.
+//
+// NewStdioStream, NewWebSocketStream, and the http.Handler returned by
+// NewHTTPHandler are the provided implementations.
+type Stream interface {
+	Read() ([]byte, error)
+	Write([]byte) error
+	Close() error
+}
+
+// malformedMessageError marks a Stream.Read error as describing one
+// malformed message rather than a dead connection: callers such as Handle
+// report a ParseError for it before ending the connection.
+type malformedMessageError struct {
+	err error
+}
+
+func (e *malformedMessageError) Error() string { return e.err.Error() }
+func (e *malformedMessageError) Unwrap() error { return e.err }
+
+type stdioStream struct {
+	rw      io.Writer
+	closer  io.Closer
+	decoder *json.Decoder
+}
+
+// NewStdioStream wraps rw as a Stream using the package's original framing:
+// values are decoded back-to-back off the stream with no delimiter required
+// between them, and every Write additionally appends a newline for
+// readability on the wire. This is the framing New, NewClient, and
+// NewClientServer have always used.
+func NewStdioStream(rw io.ReadWriter) Stream {
+	closer, ok := rw.(io.Closer)
+	if !ok {
+		closer = io.NopCloser(rw)
+	}
+
+	return &stdioStream{
+		rw:      rw,
+		closer:  closer,
+		decoder: json.NewDecoder(rw),
+	}
+}
+
+func (s *stdioStream) Read() ([]byte, error) {
+	var raw json.RawMessage
+
+	if err := s.decoder.Decode(&raw); err != nil {
+		if _, ok := err.(*json.SyntaxError); ok {
+			return nil, &malformedMessageError{err}
+		}
+
+		return nil, err
+	}
+
+	return raw, nil
+}
+
+func (s *stdioStream) Write(data []byte) error {
+	_, err := s.rw.Write(append(data, '\n'))
+
+	return err
+}
+
+func (s *stdioStream) Close() error {
+	return s.closer.Close()
+}
@@ -0,0 +1,83 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStdioStream(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	s := NewStdioStream(&buf)
+
+	if err := s.Write([]byte(`{"a":1}`)); err != nil {
+		t.Fatalf("unexpected error writing: %s", err)
+	}
+	if err := s.Write([]byte(`{"b":2}`)); err != nil {
+		t.Fatalf("unexpected error writing: %s", err)
+	}
+
+	if got, want := buf.String(), "{\"a\":1}\n{\"b\":2}\n"; got != want {
+		t.Errorf("expecting written stream %q, got %q", want, got)
+	}
+
+	for _, want := range []string{`{"a":1}`, `{"b":2}`} {
+		raw, err := s.Read()
+		if err != nil {
+			t.Fatalf("unexpected error reading: %s", err)
+		}
+		if string(raw) != want {
+			t.Errorf("expecting to read %q, got %q", want, raw)
+		}
+	}
+}
+
+func TestHTTPHandler(t *testing.T) {
+	t.Parallel()
+
+	handler := HandlerFunc(func(method string, data json.RawMessage) (any, error) {
+		return method, nil
+	})
+
+	server := httptest.NewServer(NewHTTPHandler(handler))
+	defer server.Close()
+
+	c := NewHTTPClient(server.URL, server.Client())
+
+	var result string
+	if err := c.RequestValue("echo", nil, &result); err != nil {
+		t.Fatalf("unexpected error requesting: %s", err)
+	}
+
+	if result != "echo" {
+		t.Errorf("expecting result %q, got %q", "echo", result)
+	}
+
+	if err := c.Await(-1, func(json.RawMessage) {}); err != ErrNoServerPush {
+		t.Errorf("expecting ErrNoServerPush from Await, got %s", err)
+	}
+}
+
+func TestHTTPResponseStreamRejectsSecondWrite(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+	s := &httpResponseStream{w: w}
+
+	if err := s.Write([]byte(`{}`)); err != nil {
+		t.Fatalf("unexpected error on first write: %s", err)
+	}
+
+	if err := s.Write([]byte(`{}`)); err != ErrNoServerPush {
+		t.Errorf("expecting ErrNoServerPush on second write, got %s", err)
+	}
+
+	if w.Result().StatusCode != http.StatusOK {
+		t.Errorf("expecting status %d, got %d", http.StatusOK, w.Result().StatusCode)
+	}
+}
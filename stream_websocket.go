@@ -0,0 +1,68 @@
+//go:build websocket
+
+package jsonrpc
+
+import (
+	"context"
+	"time"
+
+	"nhooyr.io/websocket"
+)
+
+// pingInterval is how often NewWebSocketStream sends a ping to the peer to
+// keep the connection, and any intermediate proxies, from timing it out.
+const pingInterval = 30 * time.Second
+
+type webSocketStream struct {
+	conn   *websocket.Conn
+	cancel context.CancelFunc
+}
+
+// NewWebSocketStream wraps conn as a Stream, sending and receiving one JSON
+// value per text frame. A background goroutine pings the peer every
+// pingInterval; nhooyr.io/websocket answers pings from the peer itself, so
+// no further handling is required on this side.
+func NewWebSocketStream(conn *websocket.Conn) Stream {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &webSocketStream{
+		conn:   conn,
+		cancel: cancel,
+	}
+
+	go s.pingLoop(ctx)
+
+	return s
+}
+
+func (s *webSocketStream) pingLoop(ctx context.Context) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if s.conn.Ping(ctx) != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *webSocketStream) Read() ([]byte, error) {
+	_, data, err := s.conn.Read(context.Background())
+
+	return data, err
+}
+
+func (s *webSocketStream) Write(data []byte) error {
+	return s.conn.Write(context.Background(), websocket.MessageText, data)
+}
+
+func (s *webSocketStream) Close() error {
+	s.cancel()
+
+	return s.conn.Close(websocket.StatusNormalClosure, "")
+}
@@ -0,0 +1,96 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNotifier(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	sh := &serverHandler{stream: NewStdioStream(&buf)}
+	n := newNotifier(sh)
+
+	sub := n.CreateSubscription()
+
+	if err := n.Notify(sub, 42); err != nil {
+		t.Fatalf("unexpected error notifying: %s", err)
+	}
+
+	var got subscriptionNotice
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid notification %q: %s", buf.Bytes(), err)
+	}
+
+	if got.Method != subscriptionMethod || got.Params.Subscription != sub.ID {
+		t.Errorf("expecting a %q notification for subscription %q, got %+v", subscriptionMethod, sub.ID, got)
+	}
+
+	n.unsubscribe(sub.ID)
+
+	if err := n.Notify(sub, 43); !errors.Is(err, ErrUnknownSubscription) {
+		t.Errorf("expecting ErrUnknownSubscription after unsubscribing, got %s", err)
+	}
+}
+
+func TestClientSubscribe(t *testing.T) {
+	t.Parallel()
+	serverConn, clientConn := makeServerClientConn()
+
+	handler := HandlerContextFunc(func(ctx context.Context, method string, data json.RawMessage) (any, error) {
+		n := NotifierFromContext(ctx)
+		sub := n.CreateSubscription()
+
+		go func() {
+			for i := 0; i < 3; i++ {
+				n.Notify(sub, i)
+			}
+		}()
+
+		return sub.ID, nil
+	})
+
+	s := New(serverConn, handler)
+	go s.Handle()
+	defer serverConn.Close()
+
+	c := NewClient(clientConn)
+	defer c.Close()
+
+	ch := make(chan int, 4)
+
+	sub, err := c.SubscribeContext(context.Background(), "sub_subscribe", nil, ch)
+	if err != nil {
+		t.Fatalf("unexpected error subscribing: %s", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		select {
+		case got := <-ch:
+			if got != i {
+				t.Errorf("expecting notification %d, got %d", i, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for notification %d", i)
+		}
+	}
+
+	if err := sub.Unsubscribe(); err != nil {
+		t.Errorf("unexpected error unsubscribing: %s", err)
+	}
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("expecting ch to be closed after Unsubscribe")
+		}
+	case <-time.After(time.Second):
+		t.Error("expecting ch to be closed after Unsubscribe")
+	}
+}